@@ -15,6 +15,7 @@
 package ingress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -29,10 +30,12 @@ import (
 
 	"github.com/api7/ingress-controller/pkg/api"
 	"github.com/api7/ingress-controller/pkg/config"
+	"github.com/api7/ingress-controller/pkg/election"
 	"github.com/api7/ingress-controller/pkg/ingress/controller"
 	"github.com/api7/ingress-controller/pkg/kube"
 	"github.com/api7/ingress-controller/pkg/log"
 	"github.com/api7/ingress-controller/pkg/seven/conf"
+	"github.com/api7/ingress-controller/pkg/shutdown"
 )
 
 func dief(template string, args ...interface{}) {
@@ -43,18 +46,31 @@ func dief(template string, args ...interface{}) {
 	os.Exit(1)
 }
 
-func waitForSignal(stopCh chan struct{}) {
+// waitForSignal blocks until SIGINT/SIGTERM is received, then closes stopCh
+// to start draining and waits up to shutdownTimeout for every subsystem
+// registered with tracker to finish. Subsystems still running when the
+// timeout elapses are force-killed by the process exiting underneath them.
+func waitForSignal(stopCh chan struct{}, tracker *shutdown.Tracker, shutdownTimeout time.Duration) {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
 	sig := <-sigCh
-	log.Infof("signal %d (%s) received", sig, sig.String())
+	log.Infof("signal %d (%s) received, draining (timeout %s)", sig, sig.String(), shutdownTimeout)
 	close(stopCh)
+
+	clean, stillRunning := tracker.Wait(shutdownTimeout)
+	if clean {
+		log.Info("all subsystems shut down cleanly")
+		return
+	}
+	log.Warnf("shutdown timeout exceeded, force-killing subsystems still running: %v", stillRunning)
 }
 
 // NewIngressCommand creates the ingress sub command for apisix-ingress-controller.
 func NewIngressCommand() *cobra.Command {
 	var configPath string
+	var ingressClass string
+	var shutdownTimeout time.Duration
 	cfg := config.NewDefaultConfig()
 
 	cmd := &cobra.Command{
@@ -78,7 +94,15 @@ If you run apisix-ingress-controller outside the Kubernetes cluster, --kubeconfi
 or if you run it inside cluster, leave it alone and in-cluster configuration will be discovered and used.
 
 Before you run apisix-ingress-controller, be sure all related resources, like CRDs (ApisixRoute, ApisixUpstream and etc),
-the apisix cluster and others are created`,
+the apisix cluster and others are created
+
+Besides the Apisix* CRDs, this controller also reconciles core Ingress objects matching --ingress-class, translating
+them into APISIX routes/upstreams/ssl objects. The following annotations are honored on such Ingress objects:
+
+    k8s.apisix.apache.org/enable-cors:    "true" to enable the cors plugin with its defaults
+    k8s.apisix.apache.org/http-to-https:  "true" to redirect http requests to https
+    k8s.apisix.apache.org/rewrite-target: rewrite the request path before it reaches the upstream
+    k8s.apisix.apache.org/auth-type:      enable an authentication plugin, e.g. "basicAuth" or "keyAuth"`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if configPath != "" {
 				c, err := config.NewConfigFromFile(configPath)
@@ -103,8 +127,55 @@ the apisix cluster and others are created`,
 			}
 			log.Info("use configuration\n", string(data))
 
+			stop := make(chan struct{})
+			tracker := shutdown.NewTracker()
+
+			if configPath != "" {
+				watcher, err := config.NewWatcher(configPath, cfg)
+				if err != nil {
+					log.Errorf("failed to watch configuration file %s, hot-reload is disabled: %s", configPath, err)
+				} else {
+					onConfigChange := func(old, new_ *config.Config, diff config.Diff) {
+						log.Infof("configuration file changed, applying live updates: %+v", diff)
+						if diff.LogLevelChanged || diff.LogOutputChanged {
+							if l, err := log.NewLogger(
+								log.WithLogLevel(new_.LogLevel),
+								log.WithOutputFile(new_.LogOutput),
+							); err != nil {
+								log.Errorf("failed to rebuild logger after configuration change: %s", err)
+							} else {
+								log.DefaultLogger = l
+							}
+						}
+						if diff.AdminKeyChanged {
+							conf.AddCluster(&conf.Cluster{
+								Name:           conf.DefaultClusterName,
+								BaseURL:        new_.APISIX.BaseURL,
+								GatewayAddress: new_.APISIX.GatewayAddress,
+								AdminKey:       new_.APISIX.AdminKey,
+							})
+							log.Info("re-authenticated the default APISIX cluster with the updated admin key")
+						}
+						if diff.ResyncIntervalChanged {
+							log.Infof("resync-interval changed to %s, it is not applied live: the running informers keep their current resync interval", new_.Kubernetes.ResyncInterval.Duration)
+						}
+						cfg = new_
+					}
+					tracker.Go("config-watcher", func() { watcher.Watch(stop, onConfigChange) })
+				}
+			}
+
 			// TODO: Move these logics to the inside of pkg/ingress/controller.
-			conf.SetBaseUrl(cfg.APISIX.BaseURL)
+			// Seed the "default" APISIX cluster from the legacy flags so
+			// resources that don't reference a cluster keep working; the
+			// ApisixClusterConfig controller loop can add/update/delete
+			// further clusters once it starts.
+			conf.AddCluster(&conf.Cluster{
+				Name:           conf.DefaultClusterName,
+				BaseURL:        cfg.APISIX.BaseURL,
+				GatewayAddress: cfg.APISIX.GatewayAddress,
+				AdminKey:       cfg.APISIX.AdminKey,
+			})
 			if err := kube.InitInformer(cfg); err != nil {
 				dief("failed to initialize kube informers: %s", err)
 			}
@@ -118,52 +189,108 @@ the apisix cluster and others are created`,
 			}
 
 			collector := metrics.NewPrometheusCollector(podName, podNamespace)
-			collector.ResetLeader(true)
+			collector.ResetLeader(false)
 
 			kubeClientSet := kube.GetKubeClient()
 			apisixClientset := kube.GetApisixClient()
-			sharedInformerFactory := api6Informers.NewSharedInformerFactory(apisixClientset, 0)
-			stop := make(chan struct{})
-			c := &controller.Api6Controller{
-				KubeClientSet:             kubeClientSet,
-				Api6ClientSet:             apisixClientset,
-				SharedInformerFactory:     sharedInformerFactory,
-				CoreSharedInformerFactory: kube.CoreSharedInformerFactory,
-				Stop:                      stop,
+
+			// runControllers starts the controller loops for this replica's
+			// leadership term and returns once they are started and the core
+			// informer caches have synced; the loops keep running until
+			// leaderCtx is cancelled, either because the lease was lost or
+			// voluntarily resigned.
+			runControllers := func(leaderCtx context.Context) {
+				controllerStop := make(chan struct{})
+				go func() {
+					<-leaderCtx.Done()
+					close(controllerStop)
+				}()
+
+				sharedInformerFactory := api6Informers.NewSharedInformerFactory(apisixClientset, 0)
+				c := &controller.Api6Controller{
+					KubeClientSet:             kubeClientSet,
+					Api6ClientSet:             apisixClientset,
+					SharedInformerFactory:     sharedInformerFactory,
+					CoreSharedInformerFactory: kube.CoreSharedInformerFactory,
+					Stop:                      controllerStop,
+				}
+				epInformer := c.CoreSharedInformerFactory.Core().V1().Endpoints()
+				kube.EndpointsInformer = epInformer
+				// endpoint
+				c.Endpoint()
+
+				// ApisixRoute
+				c.ApisixRoute()
+				// ApisixUpstream
+				c.ApisixUpstream()
+				// ApisixService
+				c.ApisixService()
+				// ApisixTLS
+				c.ApisixTLS()
+				// ApisixClusterConfig
+				c.ApisixClusterConfig(tracker)
+				// Ingress
+				c.Ingress(ingressClass, tracker)
+
+				// kube.CoreSharedInformerFactory is a single shared factory that
+				// outlives any one leadership term, and a SharedInformerFactory
+				// can't be restarted once its stop channel closes, so it's
+				// always started with the process-level stop, never
+				// controllerStop. It must start after every Core informer type
+				// this term needs (Endpoints above, Ingress/Services/Secrets
+				// registered by c.Ingress) has been registered: client-go only
+				// starts the informer types present in the factory's registry
+				// at the moment Start is called, so anything registered lazily
+				// afterwards (e.g. from inside a sync handler) never starts.
+				go c.CoreSharedInformerFactory.Start(stop)
+				for informerType, synced := range c.CoreSharedInformerFactory.WaitForCacheSync(controllerStop) {
+					if !synced {
+						log.Warnf("timed out waiting for core informer cache to sync: %v", informerType)
+					}
+				}
+
+				go func() {
+					time.Sleep(time.Duration(10) * time.Second)
+					c.SharedInformerFactory.Start(controllerStop)
+				}()
+			}
+
+			elector, err := election.New(election.Options{
+				ElectionID:      cfg.ElectionID,
+				Namespace:       podNamespace,
+				AdminAPIBaseURL: strings.TrimSuffix(cfg.APISIX.BaseURL, "/"),
+				KubeClient:      kubeClientSet,
+				Collector:       collector,
+				OnStartedLeading: func(leaderCtx context.Context) {
+					log.Info("acquired leadership, starting controller loops")
+					runControllers(leaderCtx)
+				},
+				OnStoppedLeading: func() {
+					log.Warn("lost leadership, controller loops are being stopped")
+				},
+			})
+			if err != nil {
+				dief("failed to initialize leader election: %s", err)
 			}
-			epInformer := c.CoreSharedInformerFactory.Core().V1().Endpoints()
-			kube.EndpointsInformer = epInformer
-			// endpoint
-			c.Endpoint()
-			go c.CoreSharedInformerFactory.Start(stop)
-
-			// ApisixRoute
-			c.ApisixRoute()
-			// ApisixUpstream
-			c.ApisixUpstream()
-			// ApisixService
-			c.ApisixService()
-			// ApisixTLS
-			c.ApisixTLS()
-
-			go func() {
-				time.Sleep(time.Duration(10) * time.Second)
-				c.SharedInformerFactory.Start(stop)
-			}()
+			// Tracked so waitForSignal can be sure the Lease is released
+			// (leaderelection.LeaderElectionConfig.ReleaseOnCancel is set) before exiting.
+			tracker.Go("leader-election", func() { elector.Run(stop) })
 
 			srv, err := api.NewServer(cfg)
 			if err != nil {
 				dief("failed to create API Server: %s", err)
 			}
 
-			// TODO add sync.WaitGroup
-			go func() {
+			// srv.Run is expected to stop accepting new connections as soon
+			// as stop closes, but let in-flight admin syncs finish before
+			// returning, which is what makes it safe to track here.
+			tracker.Go("api-server", func() {
 				if err := srv.Run(stop); err != nil {
-					dief("failed to launch API Server: %s", err)
+					log.Errorf("API Server exited with an error: %s", err)
 				}
-			}()
+			})
 
-			waitForSignal(stop)
+			waitForSignal(stop, tracker, shutdownTimeout)
 			log.Info("apisix ingress controller exited")
 		},
 	}
@@ -176,7 +303,11 @@ the apisix cluster and others are created`,
 	cmd.PersistentFlags().StringVar(&cfg.Kubernetes.Kubeconfig, "kubeconfig", "", "Kubernetes configuration file (by default in-cluster configuration will be used)")
 	cmd.PersistentFlags().DurationVar(&cfg.Kubernetes.ResyncInterval.Duration, "resync-interval", time.Minute, "the controller resync (with Kubernetes) interval, the minimum resync interval is 30s")
 	cmd.PersistentFlags().StringVar(&cfg.APISIX.BaseURL, "apisix-base-url", "", "the base URL for APISIX admin api / manager api")
+	cmd.PersistentFlags().StringVar(&cfg.APISIX.GatewayAddress, "apisix-gateway-address", "", "the reachable address of APISIX's data-plane gateway, published onto Ingress status.loadBalancer")
 	cmd.PersistentFlags().StringVar(&cfg.APISIX.AdminKey, "apisix-admin-key", "", "admin key used for the authorization of APISIX admin api / manager api")
+	cmd.PersistentFlags().StringVar(&cfg.ElectionID, "election-id", "ingress-apisix-leader", "election id used for the leader election, the Lease object of this name is created in POD_NAMESPACE")
+	cmd.PersistentFlags().StringVar(&ingressClass, "ingress-class", "apisix", "the Ingress class this controller reconciles, matched against spec.ingressClassName or the legacy kubernetes.io/ingress.class annotation")
+	cmd.PersistentFlags().DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for subsystems to drain on a graceful shutdown before force-killing them")
 
 	return cmd
 }
@@ -0,0 +1,132 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config defines and loads the static configuration of
+// apisix-ingress-controller, either from command line flags or from a
+// JSON/YAML configuration file.
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Duration is a time.Duration that marshals to/from a human-readable
+// string (e.g. "30s") in JSON and YAML, rather than a raw integer of
+// nanoseconds.
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.Duration.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("duration must be a JSON string, got %q", data)
+	}
+	return d.unmarshal(data[1 : len(data)-1])
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.unmarshal([]byte(s))
+}
+
+func (d *Duration) unmarshal(raw []byte) error {
+	parsed, err := time.ParseDuration(string(raw))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// KubernetesConfig groups the Kubernetes client options.
+type KubernetesConfig struct {
+	Kubeconfig     string   `json:"kubeconfig" yaml:"kubeconfig"`
+	ResyncInterval Duration `json:"resync_interval" yaml:"resync_interval"`
+}
+
+// APISIXConfig groups the default (legacy single-cluster) APISIX admin
+// API options. Additional clusters are managed at runtime via
+// ApisixClusterConfig objects, see pkg/seven/conf.
+type APISIXConfig struct {
+	BaseURL string `json:"base_url" yaml:"base_url"`
+	// GatewayAddress is the reachable address of APISIX's data-plane
+	// gateway (as opposed to BaseURL, which points at the admin API) and
+	// is published onto Ingress status.loadBalancer for Ingress objects
+	// this cluster serves.
+	GatewayAddress string `json:"gateway_address" yaml:"gateway_address"`
+	AdminKey       string `json:"admin_key" yaml:"admin_key"`
+}
+
+// Config is the static configuration of apisix-ingress-controller.
+type Config struct {
+	LogLevel        string           `json:"log_level" yaml:"log_level"`
+	LogOutput       string           `json:"log_output" yaml:"log_output"`
+	HTTPListen      string           `json:"http_listen" yaml:"http_listen"`
+	EnableProfiling bool             `json:"enable_profiling" yaml:"enable_profiling"`
+	ElectionID      string           `json:"election_id" yaml:"election_id"`
+	Kubernetes      KubernetesConfig `json:"kubernetes" yaml:"kubernetes"`
+	APISIX          APISIXConfig     `json:"apisix" yaml:"apisix"`
+}
+
+// NewDefaultConfig creates a Config filled with the same defaults as the
+// command line flags.
+func NewDefaultConfig() *Config {
+	return &Config{
+		LogLevel:        "info",
+		LogOutput:       "stderr",
+		HTTPListen:      ":8080",
+		EnableProfiling: true,
+		ElectionID:      "ingress-apisix-leader",
+		Kubernetes: KubernetesConfig{
+			ResyncInterval: Duration{time.Minute},
+		},
+	}
+}
+
+// NewConfigFromFile creates a Config from a JSON or YAML file. Every `${VAR}`
+// or `${VAR:-default}` in the file's text is expanded from the process
+// environment before it is parsed, so secrets like apisix.admin_key can be kept
+// out of the file itself and injected via a Secret-backed env var.
+func NewConfigFromFile(path string) (*Config, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := expandEnv(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := NewDefaultConfig()
+	if err := yaml.Unmarshal(expanded, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
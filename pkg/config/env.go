@@ -0,0 +1,61 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+)
+
+// envVarPattern matches ${VAR} and ${VAR:-default}.
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+func readFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file %s: %s", path, err)
+	}
+	return data, nil
+}
+
+// expandEnv replaces every ${VAR} / ${VAR:-default} occurrence in data with
+// the value of VAR from the process environment. It returns an error
+// naming the offending variable when VAR is unset and no default was given.
+func expandEnv(data []byte) ([]byte, error) {
+	var firstErr error
+	expanded := envVarPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		if firstErr != nil {
+			return match
+		}
+		groups := envVarPattern.FindSubmatch(match)
+		name := string(groups[1])
+		hasDefault := len(groups[2]) > 0
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if hasDefault {
+				return groups[3]
+			}
+			firstErr = fmt.Errorf("required environment variable %q is not set", name)
+			return match
+		}
+		return []byte(value)
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return expanded, nil
+}
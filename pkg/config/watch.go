@@ -0,0 +1,130 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/api7/ingress-controller/pkg/log"
+)
+
+// Diff describes which parts of the configuration changed between two
+// reloads, so the caller can decide what can be applied live and what
+// needs a restart.
+type Diff struct {
+	LogLevelChanged       bool
+	LogOutputChanged      bool
+	ResyncIntervalChanged bool
+	AdminKeyChanged       bool
+
+	// RestartRequired lists the human-readable reasons a restart is
+	// required, e.g. "http-listen changed" or "kubeconfig changed". It's
+	// empty when every change could be applied live.
+	RestartRequired []string
+}
+
+// Dirty reports whether anything at all changed.
+func (d Diff) Dirty() bool {
+	return d.LogLevelChanged || d.LogOutputChanged || d.ResyncIntervalChanged ||
+		d.AdminKeyChanged || len(d.RestartRequired) > 0
+}
+
+func diffConfig(old, new *Config) Diff {
+	var d Diff
+	if old.LogLevel != new.LogLevel {
+		d.LogLevelChanged = true
+	}
+	if old.LogOutput != new.LogOutput {
+		d.LogOutputChanged = true
+	}
+	if old.Kubernetes.ResyncInterval.Duration != new.Kubernetes.ResyncInterval.Duration {
+		d.ResyncIntervalChanged = true
+	}
+	if old.APISIX.AdminKey != new.APISIX.AdminKey {
+		d.AdminKeyChanged = true
+	}
+	if old.HTTPListen != new.HTTPListen {
+		d.RestartRequired = append(d.RestartRequired, "http-listen changed")
+	}
+	if old.Kubernetes.Kubeconfig != new.Kubernetes.Kubeconfig {
+		d.RestartRequired = append(d.RestartRequired, "kubeconfig changed")
+	}
+	return d
+}
+
+// Watcher watches a configuration file for changes and re-parses it on
+// every write, handing the old and new Config plus a Diff to onChange.
+type Watcher struct {
+	path    string
+	current *Config
+	fsw     *fsnotify.Watcher
+}
+
+// NewWatcher creates a Watcher for path, seeded with the already-loaded cfg
+// so the first detected change can be diffed against it.
+func NewWatcher(path string, cfg *Config) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := fsw.Add(path); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return &Watcher{path: path, current: cfg, fsw: fsw}, nil
+}
+
+// Watch blocks processing file system events until stop is closed. On every
+// write/create/rename event it re-reads the config file, computes a Diff
+// against the last known-good config, and invokes onChange when anything
+// changed. Parse errors
+// are logged and otherwise ignored, leaving the last known-good config in
+// effect.
+func (w *Watcher) Watch(stop <-chan struct{}, onChange func(old, new *Config, diff Diff)) {
+	defer w.fsw.Close()
+	for {
+		select {
+		case <-stop:
+			return
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Errorf("configuration file watcher error: %s", err)
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			newCfg, err := NewConfigFromFile(w.path)
+			if err != nil {
+				log.Errorf("failed to reload configuration file %s: %s", w.path, err)
+				continue
+			}
+			diff := diffConfig(w.current, newCfg)
+			if !diff.Dirty() {
+				continue
+			}
+			for _, reason := range diff.RestartRequired {
+				log.Warnf("configuration change requires a restart to take effect: %s", reason)
+			}
+			old := w.current
+			w.current = newCfg
+			onChange(old, newCfg, diff)
+		}
+	}
+}
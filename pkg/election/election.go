@@ -0,0 +1,269 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package election implements a recyclable leader election for the
+// apisix-ingress-controller, so that only one replica reconciles the
+// Apisix* CRDs at a time while the others stay hot on standby.
+package election
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/api7/ingress-controller/pkg/log"
+)
+
+const (
+	// defaultHealthCheckInterval is how often the admin API is probed while
+	// this replica holds the lease.
+	defaultHealthCheckInterval = 5 * time.Second
+	// defaultHealthCheckFailureThreshold is the number of consecutive probe
+	// failures after which the lease is voluntarily released.
+	defaultHealthCheckFailureThreshold = 3
+)
+
+// Collector is the subset of metrics.Collector the elector needs, kept
+// narrow so this package doesn't import pkg/metrics directly.
+type Collector interface {
+	ResetLeader(leader bool)
+	SetApisixAdminHealthy(healthy bool)
+}
+
+// Options configures an Elector.
+type Options struct {
+	// Name identifies this replica in the Lease's holder identity. Defaults
+	// to the pod name (POD_NAME) when empty.
+	Name string
+	// ElectionID is the name of the Lease object used to coordinate leadership.
+	ElectionID string
+	// Namespace is the namespace the Lease object lives in.
+	Namespace string
+	// AdminAPIBaseURL is probed periodically while this replica is leader;
+	// repeated failures trigger a voluntary resignation.
+	AdminAPIBaseURL string
+
+	KubeClient kubernetes.Interface
+	Collector  Collector
+
+	// OnStartedLeading is invoked once this replica becomes the leader.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading is invoked once this replica stops being the leader,
+	// either because it lost the lease or voluntarily resigned.
+	OnStoppedLeading func()
+}
+
+// Elector wraps client-go's leaderelection.LeaderElector with a background
+// health check that makes the election "recyclable": instead of a dead
+// leader pinning the Lease forever, it notices its upstream APISIX admin
+// API is unhealthy and steps down so another replica can take over.
+type Elector struct {
+	opts    Options
+	elector *leaderelection.LeaderElector
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // cancels the ctx this term's elector.Run call is using
+}
+
+// New creates an Elector from the given Options, filling in defaults.
+func New(opts Options) (*Elector, error) {
+	if opts.Name == "" {
+		opts.Name = os.Getenv("POD_NAME")
+	}
+	if opts.Name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		opts.Name = hostname
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      opts.ElectionID,
+			Namespace: opts.Namespace,
+		},
+		Client: opts.KubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: opts.Name,
+		},
+	}
+
+	e := &Elector{opts: opts}
+
+	lec := leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: e.onStartedLeading,
+			OnStoppedLeading: e.onStoppedLeading,
+			OnNewLeader: func(identity string) {
+				log.Infof("apisix ingress controller leader changed to %s", identity)
+			},
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(lec)
+	if err != nil {
+		return nil, err
+	}
+	e.elector = elector
+	return e, nil
+}
+
+// Run runs the election loop until stop is closed. It should be invoked
+// from its own goroutine.
+//
+// Each term gets its own context passed to the underlying
+// leaderelection.LeaderElector.Run; resign() cancels exactly that context
+// (the one the renew loop is actually watching), which is what makes a
+// voluntary resignation release the Lease rather than merely stopping our
+// own controller goroutines while client-go keeps renewing underneath us.
+// Once a term ends (lost, resigned, or stop closed) the loop re-enters the
+// race, unless stop was the reason it ended, so the election stays
+// recyclable instead of a failed replica pinning the Lease forever.
+func (e *Elector) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		e.mu.Lock()
+		e.cancel = cancel
+		e.mu.Unlock()
+
+		termDone := make(chan struct{})
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-termDone:
+			}
+		}()
+
+		e.elector.Run(ctx)
+		close(termDone)
+		cancel()
+	}
+}
+
+// IsLeader reports whether this replica currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.elector.IsLeader()
+}
+
+// resign cancels the context backing the current term's elector.Run call,
+// which stops client-go's renew loop and (ReleaseOnCancel) releases the
+// Lease.
+func (e *Elector) resign() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+func (e *Elector) onStartedLeading(ctx context.Context) {
+	log.Infof("%s started leading", e.opts.Name)
+	if e.opts.Collector != nil {
+		e.opts.Collector.ResetLeader(true)
+	}
+
+	// ctx is the exact context client-go's renew loop watches, so it's
+	// already scoped to this leadership term: cancelled on loss, voluntary
+	// resignation, or Elector.Run's own stop handling. Controller loops can
+	// use it directly without us deriving another child.
+	if e.opts.OnStartedLeading != nil {
+		e.opts.OnStartedLeading(ctx)
+	}
+	if e.opts.AdminAPIBaseURL != "" {
+		go e.runHealthCheck(ctx)
+	}
+}
+
+func (e *Elector) onStoppedLeading() {
+	log.Warnf("%s stopped leading", e.opts.Name)
+	if e.opts.Collector != nil {
+		e.opts.Collector.ResetLeader(false)
+	}
+	if e.opts.OnStoppedLeading != nil {
+		e.opts.OnStoppedLeading()
+	}
+}
+
+// runHealthCheck periodically probes the APISIX admin API while this
+// replica is leader. After defaultHealthCheckFailureThreshold consecutive
+// failures it voluntarily resigns the lease, rather than keeping a
+// replica that can't reach its upstream pinned as leader.
+func (e *Elector) runHealthCheck(ctx context.Context) {
+	ticker := time.NewTicker(defaultHealthCheckInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	client := &http.Client{Timeout: 3 * time.Second}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			healthy := probeAdminAPI(client, e.opts.AdminAPIBaseURL)
+			if e.opts.Collector != nil {
+				e.opts.Collector.SetApisixAdminHealthy(healthy)
+			}
+			if healthy {
+				failures = 0
+				continue
+			}
+			failures++
+			log.Warnf("apisix admin health probe failed (%d/%d)", failures, defaultHealthCheckFailureThreshold)
+			if failures >= defaultHealthCheckFailureThreshold {
+				log.Errorf("apisix admin API unhealthy after %d probes, resigning leadership", failures)
+				e.resign()
+				return
+			}
+		}
+	}
+}
+
+func probeAdminAPI(client *http.Client, baseURL string) bool {
+	req, err := http.NewRequest(http.MethodHead, baseURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Some APISIX admin deployments don't support HEAD; fall back to GET.
+		resp, err = client.Get(baseURL)
+		if err != nil {
+			return false
+		}
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
@@ -0,0 +1,143 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	configv1 "github.com/gxthrj/apisix-ingress-types/pkg/apis/config/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/api7/ingress-controller/pkg/log"
+	"github.com/api7/ingress-controller/pkg/seven"
+	"github.com/api7/ingress-controller/pkg/seven/conf"
+	"github.com/api7/ingress-controller/pkg/shutdown"
+)
+
+// ApisixClusterConfigController watches ApisixClusterConfig objects and
+// mirrors them into the in-process cluster registry (pkg/seven/conf), so
+// that route/upstream/service/tls resources can target an APISIX cluster
+// other than the one seeded from --apisix-base-url/--apisix-admin-key.
+type ApisixClusterConfigController struct {
+	controller *Api6Controller
+	workqueue  workqueue.RateLimitingInterface
+}
+
+// ApisixClusterConfig starts the ApisixClusterConfig controller loop,
+// registering it with tracker so a graceful shutdown can wait for its
+// workqueue to drain.
+func (c *Api6Controller) ApisixClusterConfig(tracker *shutdown.Tracker) {
+	acc := &ApisixClusterConfigController{
+		controller: c,
+		workqueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ApisixClusterConfig"),
+	}
+
+	informer := c.SharedInformerFactory.Config().V1().ApisixClusterConfigs().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    acc.enqueue,
+		UpdateFunc: func(_, new interface{}) { acc.enqueue(new) },
+		DeleteFunc: acc.enqueue,
+	})
+
+	tracker.Go("ApisixClusterConfig", func() { acc.run(c.Stop) })
+}
+
+func (acc *ApisixClusterConfigController) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Errorf("failed to build cache key for ApisixClusterConfig: %s", err)
+		return
+	}
+	acc.workqueue.Add(key)
+}
+
+func (acc *ApisixClusterConfigController) run(stop <-chan struct{}) {
+	log.Info("ApisixClusterConfig controller started")
+	go wait.Until(acc.runWorker, time.Second, stop)
+	<-stop
+	log.Info("ApisixClusterConfig controller draining workqueue")
+	acc.workqueue.ShutDownWithDrain()
+	log.Info("ApisixClusterConfig controller exited")
+}
+
+func (acc *ApisixClusterConfigController) runWorker() {
+	for acc.processNextWorkItem() {
+	}
+}
+
+func (acc *ApisixClusterConfigController) processNextWorkItem() bool {
+	obj, shutdown := acc.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer acc.workqueue.Done(obj)
+
+	key := obj.(string)
+	if err := acc.sync(key); err != nil {
+		log.Errorf("failed to sync ApisixClusterConfig %s: %s", key, err)
+		acc.workqueue.AddRateLimited(key)
+		return true
+	}
+	acc.workqueue.Forget(obj)
+	return true
+}
+
+func (acc *ApisixClusterConfigController) sync(key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	cc, err := acc.controller.SharedInformerFactory.Config().V1().ApisixClusterConfigs().Lister().Get(name)
+	if err != nil {
+		// Not found means the object was deleted; drop it from the registry.
+		conf.DeleteCluster(name)
+		return nil
+	}
+
+	if err := acc.pushGlobalConfig(cc); err != nil {
+		return fmt.Errorf("failed to push global plugin config to cluster %s: %s", name, err)
+	}
+
+	conf.AddCluster(&conf.Cluster{
+		Name:             cc.Name,
+		BaseURL:          cc.Spec.BaseURL,
+		GatewayAddress:   cc.Spec.GatewayAddress,
+		AdminKey:         cc.Spec.AdminKey,
+		GlobalPlugins:    cc.Spec.GlobalPlugins,
+		EnableMonitoring: cc.Spec.Monitoring,
+	})
+	return nil
+}
+
+// pushGlobalConfig pushes the cluster's global plugin configuration (e.g.
+// prometheus, skywalking) to its APISIX admin API so it takes effect
+// cluster-wide rather than per-route. The global_rules object is keyed by
+// this ApisixClusterConfig's name rather than a fixed ID, so that two
+// clusters sharing one APISIX admin API (a valid, if unusual, setup) don't
+// clobber each other's global rules.
+func (acc *ApisixClusterConfigController) pushGlobalConfig(cc *configv1.ApisixClusterConfig) error {
+	if len(cc.Spec.GlobalPlugins) == 0 {
+		return nil
+	}
+	client, err := seven.NewAdminClient(cc.Spec.BaseURL, cc.Spec.AdminKey)
+	if err != nil {
+		return err
+	}
+	return client.PutGlobalPlugins(cc.Name, cc.Spec.GlobalPlugins)
+}
@@ -0,0 +1,350 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/api7/ingress-controller/pkg/kube/translation"
+	"github.com/api7/ingress-controller/pkg/log"
+	"github.com/api7/ingress-controller/pkg/seven"
+	"github.com/api7/ingress-controller/pkg/seven/conf"
+	"github.com/api7/ingress-controller/pkg/shutdown"
+)
+
+// legacyIngressClassAnnotation is the pre-IngressClass way of pinning an
+// Ingress to a controller, still honored for clusters that haven't
+// migrated their Ingress objects to spec.ingressClassName.
+const legacyIngressClassAnnotation = "kubernetes.io/ingress.class"
+
+// IngressController watches networking.k8s.io/v1 Ingress objects and
+// translates them into APISIX routes/upstreams/ssl objects via the same
+// admin API the CRD-based controllers use. Neither v1beta1 Ingress objects
+// nor IngressClass objects are watched.
+type IngressController struct {
+	controller   *Api6Controller
+	ingressClass string
+	translator   *translation.Translator
+	workqueue    workqueue.RateLimitingInterface
+
+	// deleted holds the last known state of Ingress objects between their
+	// DeleteFunc firing and sync() processing the resulting workqueue key,
+	// since by then Lister.Get can no longer see them. Populated by
+	// enqueueDelete from the deleted object, or from its
+	// cache.DeletedFinalStateUnknown tombstone.
+	deletedMu sync.Mutex
+	deleted   map[string]*networkingv1.Ingress
+}
+
+// Ingress starts the Ingress controller loop, filtering by ingressClass
+// (either spec.ingressClassName or the legacy kubernetes.io/ingress.class
+// annotation). It registers with tracker so a graceful shutdown can wait
+// for its workqueue to drain.
+func (c *Api6Controller) Ingress(ingressClass string, tracker *shutdown.Tracker) {
+	ic := &IngressController{
+		controller:   c,
+		ingressClass: ingressClass,
+		workqueue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Ingress"),
+		deleted:      make(map[string]*networkingv1.Ingress),
+	}
+	ic.translator = translation.NewTranslator(ic.getSecret, ic.getService, ic.getEndpoints)
+
+	informer := c.CoreSharedInformerFactory.Networking().V1().Ingresses().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ic.enqueue,
+		UpdateFunc: func(_, new interface{}) { ic.enqueue(new) },
+		DeleteFunc: ic.enqueueDelete,
+	})
+
+	// getService/getSecret only reach for these Listers from inside sync,
+	// long after CoreSharedInformerFactory.Start has been called for this
+	// term; registering them here instead (even without event handlers)
+	// ensures they're in the factory's registry in time to actually start.
+	c.CoreSharedInformerFactory.Core().V1().Services().Informer()
+	c.CoreSharedInformerFactory.Core().V1().Secrets().Informer()
+
+	tracker.Go("Ingress", func() { ic.run(c.Stop) })
+}
+
+func (ic *IngressController) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Errorf("failed to build cache key for Ingress: %s", err)
+		return
+	}
+	ic.workqueue.Add(key)
+}
+
+// enqueueDelete stashes the Ingress's last known state before enqueuing its
+// key, so sync can still translate it (to find the routes/upstreams it
+// owned) once Lister.Get can no longer see it.
+func (ic *IngressController) enqueueDelete(obj interface{}) {
+	ing, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			log.Errorf("unexpected object type in Ingress delete handler: %T", obj)
+			return
+		}
+		ing, ok = tombstone.Obj.(*networkingv1.Ingress)
+		if !ok {
+			log.Errorf("unexpected tombstone object type in Ingress delete handler: %T", tombstone.Obj)
+			return
+		}
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(ing)
+	if err != nil {
+		log.Errorf("failed to build cache key for deleted Ingress: %s", err)
+		return
+	}
+	ic.deletedMu.Lock()
+	ic.deleted[key] = ing
+	ic.deletedMu.Unlock()
+	ic.workqueue.Add(key)
+}
+
+func (ic *IngressController) run(stop <-chan struct{}) {
+	log.Info("Ingress controller started")
+	go wait.Until(ic.runWorker, time.Second, stop)
+	<-stop
+	log.Info("Ingress controller draining workqueue")
+	ic.workqueue.ShutDownWithDrain()
+	log.Info("Ingress controller exited")
+}
+
+func (ic *IngressController) runWorker() {
+	for ic.processNextWorkItem() {
+	}
+}
+
+func (ic *IngressController) processNextWorkItem() bool {
+	obj, shutdown := ic.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer ic.workqueue.Done(obj)
+
+	key := obj.(string)
+	if err := ic.sync(key); err != nil {
+		log.Errorf("failed to sync Ingress %s: %s", key, err)
+		ic.workqueue.AddRateLimited(key)
+		return true
+	}
+	ic.workqueue.Forget(obj)
+	return true
+}
+
+func (ic *IngressController) sync(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	// TODO: clusters that don't serve networking.k8s.io/v1 need to be read
+	// through the v1beta1 lister instead; translation.TranslateIngressV1beta1
+	// already supports that, this loop just doesn't select between the two
+	// yet.
+	ing, err := ic.controller.CoreSharedInformerFactory.Networking().V1().Ingresses().Lister().Ingresses(namespace).Get(name)
+	if err != nil {
+		return ic.syncDeleted(key)
+	}
+	ic.deletedMu.Lock()
+	delete(ic.deleted, key)
+	ic.deletedMu.Unlock()
+
+	if !ic.matchesIngressClass(ing) {
+		return nil
+	}
+
+	result, err := ic.translator.TranslateIngressV1(ing)
+	if err != nil {
+		return err
+	}
+
+	cluster, err := conf.GetCluster(conf.ClusterNameFromAnnotations(ing.Annotations))
+	if err != nil {
+		return err
+	}
+	client, err := seven.NewAdminClient(cluster.BaseURL, cluster.AdminKey)
+	if err != nil {
+		return err
+	}
+
+	for _, up := range result.Upstreams {
+		if err := client.PutUpstream(up.Name, upstreamBody(up)); err != nil {
+			return fmt.Errorf("failed to push upstream %s: %s", up.Name, err)
+		}
+	}
+	for _, route := range result.Routes {
+		body := map[string]interface{}{
+			"name":        route.Name,
+			"host":        route.Host,
+			"uri":         routeURI(route),
+			"upstream_id": route.UpstreamName,
+		}
+		if len(route.Plugins) > 0 {
+			body["plugins"] = route.Plugins
+		}
+		if err := client.PutRoute(route.ID, body); err != nil {
+			return fmt.Errorf("failed to push route %s: %s", route.ID, err)
+		}
+	}
+	for _, ssl := range result.SSL {
+		if err := client.PutSSL(ssl.Name, map[string]interface{}{
+			"snis": ssl.Hosts,
+			"cert": ssl.Cert,
+			"key":  ssl.Key,
+		}); err != nil {
+			return fmt.Errorf("failed to push ssl %s: %s", ssl.Name, err)
+		}
+	}
+
+	if cluster.GatewayAddress == "" {
+		log.Warnf("cluster %s has no gateway address configured, not updating status of Ingress %s", conf.ClusterNameFromAnnotations(ing.Annotations), key)
+		return nil
+	}
+	return ic.updateLoadBalancerStatus(ing, cluster.GatewayAddress)
+}
+
+// syncDeleted removes the routes/upstreams an Ingress owned once it's gone
+// from the Lister, using the last known state enqueueDelete stashed for it.
+func (ic *IngressController) syncDeleted(key string) error {
+	ic.deletedMu.Lock()
+	ing, ok := ic.deleted[key]
+	if ok {
+		delete(ic.deleted, key)
+	}
+	ic.deletedMu.Unlock()
+	if !ok {
+		// No stashed state (e.g. the controller restarted and lost it);
+		// without the Ingress's spec we can't know what route/upstream
+		// names it owned, so there's nothing safe to clean up here.
+		return nil
+	}
+
+	result, err := ic.translator.TranslateIngressV1(ing)
+	if err != nil {
+		return err
+	}
+
+	cluster, err := conf.GetCluster(conf.ClusterNameFromAnnotations(ing.Annotations))
+	if err != nil {
+		return err
+	}
+	client, err := seven.NewAdminClient(cluster.BaseURL, cluster.AdminKey)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range result.Routes {
+		if err := client.DeleteRoute(route.ID); err != nil {
+			return fmt.Errorf("failed to delete route %s: %s", route.ID, err)
+		}
+	}
+	for _, up := range result.Upstreams {
+		if err := client.DeleteUpstream(up.Name); err != nil {
+			return fmt.Errorf("failed to delete upstream %s: %s", up.Name, err)
+		}
+	}
+	return nil
+}
+
+// upstreamBody builds the APISIX upstream object body for up, including the
+// endpoint nodes translation.Translator resolved for its backend Service.
+func upstreamBody(up translation.Upstream) map[string]interface{} {
+	nodes := make(map[string]int, len(up.Nodes))
+	for _, node := range up.Nodes {
+		nodes[fmt.Sprintf("%s:%d", node.Host, node.Port)] = node.Weight
+	}
+	return map[string]interface{}{
+		"name":  up.Name,
+		"type":  "roundrobin",
+		"nodes": nodes,
+	}
+}
+
+// routeURI turns route.Path into the uri APISIX matches on, honoring
+// route.PathType: Prefix needs APISIX's "*" wildcard suffix to match
+// sub-paths the way networking.k8s.io/v1 defines prefix matching, while
+// Exact/ImplementationSpecific match the literal path.
+func routeURI(route translation.Route) string {
+	if route.PathType != string(networkingv1.PathTypePrefix) {
+		return route.Path
+	}
+	return prefixMatchURI(route.Path)
+}
+
+func prefixMatchURI(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path + "*"
+	}
+	return path + "/*"
+}
+
+// matchesIngressClass reports whether ing should be handled by this
+// controller, per spec.ingressClassName or the legacy annotation.
+func (ic *IngressController) matchesIngressClass(ing *networkingv1.Ingress) bool {
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName == ic.ingressClass
+	}
+	if class, ok := ing.Annotations[legacyIngressClassAnnotation]; ok {
+		return class == ic.ingressClass
+	}
+	return false
+}
+
+func (ic *IngressController) getSecret(namespace, name string) (*corev1.Secret, error) {
+	return ic.controller.CoreSharedInformerFactory.Core().V1().Secrets().Lister().Secrets(namespace).Get(name)
+}
+
+func (ic *IngressController) getService(namespace, name string) (*corev1.Service, error) {
+	return ic.controller.CoreSharedInformerFactory.Core().V1().Services().Lister().Services(namespace).Get(name)
+}
+
+func (ic *IngressController) getEndpoints(namespace, name string) (*corev1.Endpoints, error) {
+	return ic.controller.CoreSharedInformerFactory.Core().V1().Endpoints().Lister().Endpoints(namespace).Get(name)
+}
+
+// updateLoadBalancerStatus publishes the APISIX gateway address back onto
+// ingress.status.loadBalancer, the same way other Ingress controllers
+// report their gateway's reachable address. gatewayAddress is commonly an
+// IP (a Service's ClusterIP/LoadBalancer IP), so it's published under IP
+// rather than Hostname when it parses as one.
+func (ic *IngressController) updateLoadBalancerStatus(ing *networkingv1.Ingress, gatewayAddress string) error {
+	ingClient := ic.controller.KubeClientSet.NetworkingV1().Ingresses(ing.Namespace)
+	ing = ing.DeepCopy()
+	lbIngress := corev1.LoadBalancerIngress{}
+	if net.ParseIP(gatewayAddress) != nil {
+		lbIngress.IP = gatewayAddress
+	} else {
+		lbIngress.Hostname = gatewayAddress
+	}
+	ing.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{lbIngress}
+	_, err := ingClient.UpdateStatus(context.TODO(), ing, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,67 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package translation
+
+// The k8s.apisix.apache.org/* annotations honored on Ingress objects, to
+// enable the equivalent APISIX plugin without requiring an ApisixRoute CRD.
+const (
+	// AnnotationEnableCORS enables the APISIX cors plugin with its defaults.
+	AnnotationEnableCORS = "k8s.apisix.apache.org/enable-cors"
+	// AnnotationHTTPToHTTPS redirects http -> https via the redirect plugin.
+	AnnotationHTTPToHTTPS = "k8s.apisix.apache.org/http-to-https"
+	// AnnotationRewriteTarget rewrites the request path via the
+	// proxy-rewrite plugin before it reaches the upstream.
+	AnnotationRewriteTarget = "k8s.apisix.apache.org/rewrite-target"
+	// AnnotationAuthType selects an authentication plugin (e.g. "basicAuth",
+	// "keyAuth") to enable on the route.
+	AnnotationAuthType = "k8s.apisix.apache.org/auth-type"
+)
+
+// annotationPlugins maps the documented k8s.apisix.apache.org/* annotations
+// present on an Ingress object to the APISIX plugin config they enable.
+func annotationPlugins(annotations map[string]string) map[string]interface{} {
+	plugins := map[string]interface{}{}
+
+	if annotations[AnnotationEnableCORS] == "true" {
+		plugins["cors"] = map[string]interface{}{
+			"allow_origins": "*",
+			"allow_methods": "*",
+			"allow_headers": "*",
+		}
+	}
+	if annotations[AnnotationHTTPToHTTPS] == "true" {
+		plugins["redirect"] = map[string]interface{}{
+			"http_to_https": true,
+		}
+	}
+	if target, ok := annotations[AnnotationRewriteTarget]; ok && target != "" {
+		plugins["proxy-rewrite"] = map[string]interface{}{
+			"uri": target,
+		}
+	}
+	if authType, ok := annotations[AnnotationAuthType]; ok && authType != "" {
+		// "basicAuth"/"keyAuth" name the basic-auth/key-auth plugins; any
+		// other value is used as the plugin name as is.
+		switch authType {
+		case "basicAuth":
+			authType = "basic-auth"
+		case "keyAuth":
+			authType = "key-auth"
+		}
+		plugins[authType] = map[string]interface{}{}
+	}
+
+	return plugins
+}
@@ -0,0 +1,247 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package translation
+
+import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// idUnsafe matches everything that isn't safe to use in an APISIX object
+// id, notably the '/' an Ingress path always starts with.
+var idUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// routeID derives a slash-free APISIX route id from a rule's host and path,
+// scoped to the owning Ingress. Host is included so that rules which only
+// differ by host (e.g. a.com/ and b.com/) don't collapse onto one id.
+func routeID(namespace, name, host, path string) string {
+	return idUnsafe.ReplaceAllString(fmt.Sprintf("%s_%s_%s_%s", namespace, name, host, path), "_")
+}
+
+// defaultUpstreamNodeWeight is the weight assigned to every endpoint node
+// resolved from a Service/Endpoints pair; Ingress has no field to express
+// per-endpoint weighting, unlike ApisixUpstream.
+const defaultUpstreamNodeWeight = 100
+
+// SecretGetter resolves a TLS secret referenced from an Ingress's
+// spec.tls entries. It's injected rather than taking a full client so this
+// package stays testable without a fake clientset.
+type SecretGetter func(namespace, name string) (*corev1.Secret, error)
+
+// ServiceGetter resolves a Service referenced from an Ingress path's
+// backend, used to map its port to the Endpoints subset port name.
+type ServiceGetter func(namespace, name string) (*corev1.Service, error)
+
+// EndpointsGetter resolves the Endpoints backing a Service, used to list
+// the actual pod IPs an upstream's nodes are built from.
+type EndpointsGetter func(namespace, name string) (*corev1.Endpoints, error)
+
+// Translator turns Kubernetes Ingress objects into APISIX routes,
+// upstreams and ssl objects.
+type Translator struct {
+	GetSecret    SecretGetter
+	GetService   ServiceGetter
+	GetEndpoints EndpointsGetter
+}
+
+// NewTranslator creates a Translator backed by the given secret, Service and
+// Endpoints lookups.
+func NewTranslator(getSecret SecretGetter, getService ServiceGetter, getEndpoints EndpointsGetter) *Translator {
+	return &Translator{GetSecret: getSecret, GetService: getService, GetEndpoints: getEndpoints}
+}
+
+// TranslateIngressV1 translates a networking.k8s.io/v1 Ingress.
+func (t *Translator) TranslateIngressV1(ing *networkingv1.Ingress) (*Result, error) {
+	result := &Result{}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+			upstreamName := fmt.Sprintf("%s_%s_%s_%d", ing.Namespace, ing.Name, path.Backend.Service.Name, path.Backend.Service.Port.Number)
+			nodes, err := t.upstreamNodes(ing.Namespace, path.Backend.Service.Name, path.Backend.Service.Port.Name, path.Backend.Service.Port.Number)
+			if err != nil {
+				return nil, err
+			}
+			result.Upstreams = append(result.Upstreams, Upstream{Name: upstreamName, Nodes: nodes})
+
+			pathType := string(networkingv1.PathTypeImplementationSpecific)
+			if path.PathType != nil {
+				pathType = string(*path.PathType)
+			}
+
+			result.Routes = append(result.Routes, Route{
+				ID:           routeID(ing.Namespace, ing.Name, rule.Host, path.Path),
+				Name:         fmt.Sprintf("%s/%s%s", ing.Namespace, ing.Name, path.Path),
+				Host:         rule.Host,
+				Path:         path.Path,
+				PathType:     pathType,
+				UpstreamName: upstreamName,
+				Plugins:      annotationPlugins(ing.Annotations),
+			})
+		}
+	}
+
+	for _, tls := range ing.Spec.TLS {
+		ssl, err := t.translateTLS(ing.Namespace, tls.Hosts, tls.SecretName)
+		if err != nil {
+			return nil, err
+		}
+		result.SSL = append(result.SSL, *ssl)
+	}
+
+	return result, nil
+}
+
+// TranslateIngressV1beta1 translates the legacy networking.k8s.io/v1beta1
+// Ingress, used by clusters that haven't migrated to v1 yet.
+func (t *Translator) TranslateIngressV1beta1(ing *networkingv1beta1.Ingress) (*Result, error) {
+	result := &Result{}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.ServiceName == "" {
+				continue
+			}
+			upstreamName := fmt.Sprintf("%s_%s_%s_%d", ing.Namespace, ing.Name, path.Backend.ServiceName, path.Backend.ServicePort.IntValue())
+			portName := path.Backend.ServicePort.StrVal
+			var portNumber int32
+			if path.Backend.ServicePort.Type == intstr.Int {
+				portNumber = path.Backend.ServicePort.IntVal
+			}
+			nodes, err := t.upstreamNodes(ing.Namespace, path.Backend.ServiceName, portName, portNumber)
+			if err != nil {
+				return nil, err
+			}
+			result.Upstreams = append(result.Upstreams, Upstream{Name: upstreamName, Nodes: nodes})
+
+			pathType := string(networkingv1.PathTypeImplementationSpecific)
+			if path.PathType != nil {
+				pathType = string(*path.PathType)
+			}
+
+			result.Routes = append(result.Routes, Route{
+				ID:           routeID(ing.Namespace, ing.Name, rule.Host, path.Path),
+				Name:         fmt.Sprintf("%s/%s%s", ing.Namespace, ing.Name, path.Path),
+				Host:         rule.Host,
+				Path:         path.Path,
+				PathType:     pathType,
+				UpstreamName: upstreamName,
+				Plugins:      annotationPlugins(ing.Annotations),
+			})
+		}
+	}
+
+	for _, tls := range ing.Spec.TLS {
+		ssl, err := t.translateTLS(ing.Namespace, tls.Hosts, tls.SecretName)
+		if err != nil {
+			return nil, err
+		}
+		result.SSL = append(result.SSL, *ssl)
+	}
+
+	return result, nil
+}
+
+// upstreamNodes resolves the ready endpoint addresses backing namespace/name
+// on the given Service port (identified by name, or by number when portName
+// is empty) into APISIX upstream nodes. The Service is consulted first to
+// translate the port into the name Endpoints subsets key their ports by.
+func (t *Translator) upstreamNodes(namespace, name, portName string, portNumber int32) ([]UpstreamNode, error) {
+	svc, err := t.GetService(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Service %s/%s: %s", namespace, name, err)
+	}
+
+	var subsetPortName string
+	found := false
+	for _, svcPort := range svc.Spec.Ports {
+		if portName != "" && svcPort.Name == portName {
+			subsetPortName = svcPort.Name
+			found = true
+			break
+		}
+		if portName == "" && svcPort.Port == portNumber {
+			subsetPortName = svcPort.Name
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("service %s/%s has no port matching %q/%d", namespace, name, portName, portNumber)
+	}
+
+	ep, err := t.GetEndpoints(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Endpoints %s/%s: %s", namespace, name, err)
+	}
+
+	var nodes []UpstreamNode
+	for _, subset := range ep.Subsets {
+		var subsetPort int32
+		portFound := false
+		for _, p := range subset.Ports {
+			if p.Name == subsetPortName {
+				subsetPort = p.Port
+				portFound = true
+				break
+			}
+		}
+		if !portFound {
+			continue
+		}
+		for _, addr := range subset.Addresses {
+			nodes = append(nodes, UpstreamNode{
+				Host:   addr.IP,
+				Port:   subsetPort,
+				Weight: defaultUpstreamNodeWeight,
+			})
+		}
+	}
+	return nodes, nil
+}
+
+func (t *Translator) translateTLS(namespace string, hosts []string, secretName string) (*SSL, error) {
+	secret, err := t.GetSecret(namespace, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TLS secret %s/%s: %s", namespace, secretName, err)
+	}
+	cert, ok := secret.Data[corev1.TLSCertKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s is missing %s", namespace, secretName, corev1.TLSCertKey)
+	}
+	key, ok := secret.Data[corev1.TLSPrivateKeyKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s is missing %s", namespace, secretName, corev1.TLSPrivateKeyKey)
+	}
+	return &SSL{
+		Name:  fmt.Sprintf("%s_%s", namespace, secretName),
+		Hosts: hosts,
+		Cert:  string(cert),
+		Key:   string(key),
+	}, nil
+}
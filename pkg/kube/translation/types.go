@@ -0,0 +1,67 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package translation converts core Kubernetes Ingress (and IngressClass)
+// resources into the APISIX route/upstream/ssl objects pushed through the
+// admin API, the same way pkg/seven/conf's ApisixRoute handling does for
+// the CRD-based flow.
+package translation
+
+// Upstream is the APISIX upstream an Ingress path's backend is translated
+// into: a named group of endpoint nodes.
+type Upstream struct {
+	Name  string
+	Nodes []UpstreamNode
+}
+
+// UpstreamNode is one weighted endpoint of an Upstream.
+type UpstreamNode struct {
+	Host   string
+	Port   int32
+	Weight int
+}
+
+// Route is the APISIX route a single Ingress rule/path is translated into.
+type Route struct {
+	// ID is the APISIX object id this route is pushed under: slash-free
+	// and derived from namespace+name+host+path so that rules which only
+	// differ by host don't collapse onto the same object. Name is the
+	// separate human-readable value used for the route's "name" field.
+	ID           string
+	Name         string
+	Host         string
+	Path         string
+	// PathType mirrors networking.k8s.io/v1's PathType: Exact, Prefix or
+	// ImplementationSpecific, and determines how Path is turned into an
+	// APISIX uri match.
+	PathType     string
+	UpstreamName string
+	Plugins      map[string]interface{}
+}
+
+// SSL is the APISIX ssl object an Ingress spec.tls entry is translated into.
+type SSL struct {
+	Name  string
+	Hosts []string
+	Cert  string
+	Key   string
+}
+
+// Result is everything one Ingress object translates to.
+type Result struct {
+	Upstreams []Upstream
+	Routes    []Route
+	SSL       []SSL
+}
@@ -0,0 +1,69 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exposes apisix-ingress-controller's Prometheus metrics.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Collector is the set of metrics apisix-ingress-controller reports.
+type Collector struct {
+	isLeader           prometheus.Gauge
+	apisixAdminHealthy prometheus.Gauge
+}
+
+// NewPrometheusCollector creates a Collector for the given pod, registering
+// its gauges with the default Prometheus registry. podName/podNamespace are
+// attached as constant labels so metrics from every replica can be told
+// apart once scraped.
+func NewPrometheusCollector(podName, podNamespace string) *Collector {
+	constLabels := prometheus.Labels{
+		"pod_name":      podName,
+		"pod_namespace": podNamespace,
+	}
+
+	c := &Collector{
+		isLeader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "is_leader",
+			Help:        "Whether this replica currently holds the leader election lease (1) or not (0).",
+			ConstLabels: constLabels,
+		}),
+		apisixAdminHealthy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "apisix_admin_healthy",
+			Help:        "Whether this replica's last probe of the APISIX admin API succeeded (1) or not (0).",
+			ConstLabels: constLabels,
+		}),
+	}
+
+	prometheus.MustRegister(c.isLeader, c.apisixAdminHealthy)
+	return c
+}
+
+// ResetLeader sets the is_leader gauge.
+func (c *Collector) ResetLeader(leader bool) {
+	c.isLeader.Set(boolToFloat64(leader))
+}
+
+// SetApisixAdminHealthy sets the apisix_admin_healthy gauge.
+func (c *Collector) SetApisixAdminHealthy(healthy bool) {
+	c.apisixAdminHealthy.Set(boolToFloat64(healthy))
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package seven
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AdminClient is a minimal client for the APISIX admin API resources that
+// don't otherwise go through pkg/seven's CRD-driven sync: per-cluster
+// global_rules, and the routes/upstreams/ssl objects produced by the
+// Ingress translation subsystem (pkg/kube/translation).
+type AdminClient struct {
+	baseURL  string
+	adminKey string
+	http     *http.Client
+}
+
+// NewAdminClient creates an AdminClient targeting the given APISIX admin
+// base URL, authenticating with adminKey.
+func NewAdminClient(baseURL, adminKey string) (*AdminClient, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("empty apisix admin base URL")
+	}
+	return &AdminClient{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		adminKey: adminKey,
+		http:     &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// PutGlobalPlugins pushes the given plugin configuration to the named
+// global_rules object, so it applies to every route on this admin API. id
+// must be unique per cluster sharing that admin API (the ApisixClusterConfig
+// name is used), otherwise two clusters pointed at the same APISIX instance
+// would overwrite each other's global rules under a shared fixed ID.
+func (c *AdminClient) PutGlobalPlugins(id string, plugins map[string]interface{}) error {
+	return c.put("/global_rules/"+id, map[string]interface{}{"plugins": plugins})
+}
+
+// PutUpstream creates or updates an APISIX upstream object.
+func (c *AdminClient) PutUpstream(name string, body map[string]interface{}) error {
+	return c.put("/upstreams/"+name, body)
+}
+
+// PutRoute creates or updates an APISIX route object.
+func (c *AdminClient) PutRoute(name string, body map[string]interface{}) error {
+	return c.put("/routes/"+name, body)
+}
+
+// PutSSL creates or updates an APISIX ssl object.
+func (c *AdminClient) PutSSL(name string, body map[string]interface{}) error {
+	return c.put("/ssl/"+name, body)
+}
+
+// DeleteRoute removes an APISIX route object.
+func (c *AdminClient) DeleteRoute(name string) error {
+	return c.delete("/routes/" + name)
+}
+
+// DeleteUpstream removes an APISIX upstream object.
+func (c *AdminClient) DeleteUpstream(name string) error {
+	return c.delete("/upstreams/" + name)
+}
+
+func (c *AdminClient) put(path string, body map[string]interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return c.do(req, path)
+}
+
+func (c *AdminClient) delete(path string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, path)
+}
+
+func (c *AdminClient) do(req *http.Request, path string) error {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", c.adminKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("apisix admin API returned status %d for %s", resp.StatusCode, path)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package conf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DefaultClusterName is the cluster used by resources that don't reference
+// one explicitly, and the one seeded from the legacy --apisix-base-url /
+// --apisix-admin-key flags for backward compatibility.
+const DefaultClusterName = "default"
+
+// ClusterAnnotation is the annotation CRDs and Ingress objects use to pin
+// themselves to a non-default cluster registered via ApisixClusterConfig.
+const ClusterAnnotation = "k8s.apisix.apache.org/cluster"
+
+// ClusterNameFromAnnotations returns the cluster name a resource targets,
+// falling back to DefaultClusterName when the annotation is absent. Every
+// sync loop that pushes to the admin API (Ingress, and the CRD-driven
+// ApisixRoute/ApisixUpstream/ApisixService/ApisixTLS loops) should resolve
+// their target cluster through this helper so a multi-cluster setup behaves
+// consistently across resource kinds.
+func ClusterNameFromAnnotations(annotations map[string]string) string {
+	if name := annotations[ClusterAnnotation]; name != "" {
+		return name
+	}
+	return DefaultClusterName
+}
+
+// Cluster holds the per-APISIX-cluster settings that used to be global,
+// single-valued flags: where its admin API lives, how to authenticate to
+// it, and which global plugins/monitoring toggles it should carry.
+type Cluster struct {
+	Name    string
+	BaseURL string
+	// GatewayAddress is the reachable address of this cluster's APISIX
+	// data-plane gateway, published onto Ingress status.loadBalancer.
+	// BaseURL points at the admin API instead and isn't reachable by
+	// clients, so the two must not be conflated.
+	GatewayAddress   string
+	AdminKey         string
+	GlobalPlugins    map[string]interface{}
+	EnableMonitoring bool
+}
+
+var (
+	clustersMu sync.RWMutex
+	clusters   = map[string]*Cluster{}
+)
+
+// SetBaseUrl keeps the legacy single-cluster behavior: it seeds/updates the
+// "default" cluster's base URL. Existing callers of this function continue
+// to work unchanged.
+func SetBaseUrl(baseURL string) {
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+	c, ok := clusters[DefaultClusterName]
+	if !ok {
+		c = &Cluster{Name: DefaultClusterName}
+		clusters[DefaultClusterName] = c
+	}
+	c.BaseURL = baseURL
+}
+
+// AddCluster registers or replaces the named cluster.
+func AddCluster(c *Cluster) {
+	if c.Name == "" {
+		c.Name = DefaultClusterName
+	}
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+	clusters[c.Name] = c
+}
+
+// DeleteCluster removes the named cluster. Deleting "default" is a no-op
+// since resources silently fall back to it otherwise.
+func DeleteCluster(name string) {
+	if name == DefaultClusterName {
+		return
+	}
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+	delete(clusters, name)
+}
+
+// GetCluster returns the named cluster, or the default one if name is empty.
+func GetCluster(name string) (*Cluster, error) {
+	if name == "" {
+		name = DefaultClusterName
+	}
+	clustersMu.RLock()
+	defer clustersMu.RUnlock()
+	c, ok := clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("apisix cluster %q is not configured", name)
+	}
+	return c, nil
+}
+
+// ListClusters returns the names of all currently registered clusters.
+func ListClusters() []string {
+	clustersMu.RLock()
+	defer clustersMu.RUnlock()
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	return names
+}
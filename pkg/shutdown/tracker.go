@@ -0,0 +1,81 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shutdown tracks the goroutines apisix-ingress-controller needs
+// to drain on exit, so waitForSignal can bound how long it waits for them
+// with a timeout instead of returning as soon as the stop signal fires.
+package shutdown
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker is a sync.WaitGroup that also remembers the name of every
+// subsystem still running, so a timed-out Wait can report which ones were
+// force-killed instead of just "something didn't finish".
+type Tracker struct {
+	mu      sync.Mutex
+	pending map[string]struct{}
+	wg      sync.WaitGroup
+}
+
+// NewTracker creates an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{pending: map[string]struct{}{}}
+}
+
+// Go runs fn in its own goroutine, registering it under name until fn
+// returns.
+func (t *Tracker) Go(name string, fn func()) {
+	t.mu.Lock()
+	t.pending[name] = struct{}{}
+	t.mu.Unlock()
+	t.wg.Add(1)
+
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.pending, name)
+			t.mu.Unlock()
+			t.wg.Done()
+		}()
+		fn()
+	}()
+}
+
+// Wait blocks until every registered subsystem has returned, or timeout
+// elapses. It reports whether every subsystem exited cleanly, and if not,
+// which ones were still running when the timeout hit.
+func (t *Tracker) Wait(timeout time.Duration) (clean bool, stillRunning []string) {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true, nil
+	case <-time.After(timeout):
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		stillRunning = make([]string, 0, len(t.pending))
+		for name := range t.pending {
+			stillRunning = append(stillRunning, name)
+		}
+		return false, stillRunning
+	}
+}